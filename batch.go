@@ -0,0 +1,190 @@
+package pgx
+
+import "fmt"
+
+// Batch queues a series of queries to be sent to the server as a single
+// pipelined round trip via Conn.SendBatch, instead of the usual one
+// round trip per query.
+type Batch struct {
+	items []*batchItem
+}
+
+type batchItem struct {
+	query string
+	args  []interface{}
+}
+
+// Queue adds query and its args to the batch. It does not send anything
+// over the wire; the batch is only flushed by SendBatch.
+func (b *Batch) Queue(query string, args ...interface{}) {
+	b.items = append(b.items, &batchItem{query: query, args: args})
+}
+
+// Len returns the number of queries currently queued.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// SendBatch flushes b as a single pipelined round trip: a Parse, Bind,
+// Describe, and Execute for every queued item, followed by one Sync. The
+// server begins responding to each item without waiting for the client to
+// finish sending, and without waiting for previous items' results, which
+// is what makes batching faster than issuing the same statements one at a
+// time.
+//
+// The returned BatchResults must be consumed, via Exec/Query/QueryRow in
+// the order the statements were queued, and Close must be called even if
+// an earlier result errored, so the connection is left synced and usable
+// for the next query.
+func (c *Conn) SendBatch(b *Batch) (*BatchResults, error) {
+	for _, item := range b.items {
+		ps, err := c.prepareExtendedQuery(item.query)
+		if err != nil {
+			return &BatchResults{conn: c, err: err}, err
+		}
+
+		if err := c.sendParse(ps); err != nil {
+			return &BatchResults{conn: c, err: err}, err
+		}
+		if err := c.sendBind(ps, item.args); err != nil {
+			return &BatchResults{conn: c, err: err}, err
+		}
+		if err := c.sendDescribePortal(); err != nil {
+			return &BatchResults{conn: c, err: err}, err
+		}
+		if err := c.sendExecute(); err != nil {
+			return &BatchResults{conn: c, err: err}, err
+		}
+	}
+
+	if err := c.sendSync(); err != nil {
+		return &BatchResults{conn: c, err: err}, err
+	}
+
+	return &BatchResults{conn: c, items: b.items}, nil
+}
+
+// BatchResults reads the responses to a batch's queries, in the order they
+// were queued.
+type BatchResults struct {
+	conn     *Conn
+	items    []*batchItem
+	position int
+	err      error
+	closed   bool
+}
+
+// result reads the next queued item's response off the wire, whatever
+// shape it turns out to be (a row-returning statement yields rows, a
+// command like INSERT yields only a CommandTag), advancing position and
+// making any error sticky on br so every later call, up to Close, returns
+// it instead of reading the stream again out of step with the items that
+// were actually queued.
+func (br *BatchResults) result() (*Rows, CommandTag, error) {
+	if br.err != nil {
+		return nil, "", br.err
+	}
+	if br.position >= len(br.items) {
+		err := fmt.Errorf("batch: no more results, %d queries were queued", len(br.items))
+		br.err = err
+		return nil, "", err
+	}
+	br.position++
+
+	rows, ct, err := br.conn.readBatchResult()
+	if err != nil {
+		br.err = err
+	}
+	return rows, ct, err
+}
+
+// Exec reads the results of the next queued query, expecting it to be one
+// that does not return rows. If it does return rows, they are drained so
+// the connection isn't left desynced for the next result.
+func (br *BatchResults) Exec() (CommandTag, error) {
+	rows, ct, err := br.result()
+	if err != nil {
+		return "", err
+	}
+
+	if rows != nil {
+		for rows.Next() {
+		}
+		if err := rows.Err(); err != nil {
+			br.err = err
+			return "", err
+		}
+		return rows.CommandTag(), nil
+	}
+
+	return ct, nil
+}
+
+// Query reads the results of the next queued query, expecting it to be one
+// that returns rows. The returned Rows must be closed, same as one from
+// Conn.Query, before the next BatchResults method is called.
+func (br *BatchResults) Query() (*Rows, error) {
+	rows, ct, err := br.result()
+	if err != nil {
+		return nil, err
+	}
+
+	if rows == nil {
+		err := fmt.Errorf("batch: queued statement at this position returned command tag %q, not rows", ct)
+		br.err = err
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// QueryRow reads the results of the next queued query as a single row, the
+// same convenience Conn.QueryRow provides for a non-batched query.
+func (br *BatchResults) QueryRow() *Row {
+	rows, _ := br.Query()
+	return (*Row)(rows)
+}
+
+// Close drains any unread results, by whatever message shape they turn out
+// to be rather than assuming every item is Exec-shaped, and issues the
+// final Sync acknowledgment so the underlying connection is left usable
+// for the next query, regardless of whether every queued query's result
+// was read or whether any of them errored.
+//
+// Once an item errors -- whether that happened on an earlier Exec/Query
+// call (br.err already set on entry) or right here in the drain loop --
+// the server discards every remaining queued item as a single unit and
+// emits just one ReadyForQuery, not one response per remaining item, so
+// the loop stops reading per-item results the moment an error is seen
+// instead of assuming one is still waiting for every item left in br.items.
+func (br *BatchResults) Close() error {
+	if br.closed {
+		return br.err
+	}
+	br.closed = true
+
+	for br.err == nil && br.position < len(br.items) {
+		br.position++
+
+		rows, _, err := br.conn.readBatchResult()
+		if err != nil {
+			br.err = err
+			break
+		}
+
+		if rows != nil {
+			for rows.Next() {
+			}
+			if err := rows.Err(); err != nil {
+				br.err = err
+				break
+			}
+		}
+	}
+
+	if err := br.conn.readReadyForQuery(); err != nil && br.err == nil {
+		br.err = err
+	}
+
+	return br.err
+}
@@ -0,0 +1,112 @@
+package pgx
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+// cancelRequestCode is the protocol version PostgreSQL recognizes as a
+// CancelRequest rather than a StartupMessage.
+const cancelRequestCode = 80877102
+
+// QueryContext is like Query, except the query is interrupted with a
+// server-side CancelRequest if ctx is canceled or its deadline expires
+// before the query finishes.
+func (c *Conn) QueryContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	done := c.watchContext(ctx)
+	rows, err := c.Query(sql, args...)
+	if err != nil {
+		done()
+		return rows, err
+	}
+	rows.afterClose(done)
+	return rows, nil
+}
+
+// QueryRowContext is the QueryContext equivalent of QueryRow.
+func (c *Conn) QueryRowContext(ctx context.Context, sql string, args ...interface{}) *Row {
+	rows, _ := c.QueryContext(ctx, sql, args...)
+	return (*Row)(rows)
+}
+
+// ExecContext is the QueryContext equivalent of Exec.
+func (c *Conn) ExecContext(ctx context.Context, sql string, args ...interface{}) (CommandTag, error) {
+	done := c.watchContext(ctx)
+	defer done()
+
+	return c.Exec(sql, args...)
+}
+
+// QueryContext is the Tx equivalent of Conn.QueryContext.
+func (tx *Tx) QueryContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	return tx.conn.QueryContext(ctx, sql, args...)
+}
+
+// QueryRowContext is the Tx equivalent of Conn.QueryRowContext.
+func (tx *Tx) QueryRowContext(ctx context.Context, sql string, args ...interface{}) *Row {
+	return tx.conn.QueryRowContext(ctx, sql, args...)
+}
+
+// ExecContext is the Tx equivalent of Conn.ExecContext.
+func (tx *Tx) ExecContext(ctx context.Context, sql string, args ...interface{}) (CommandTag, error) {
+	return tx.conn.ExecContext(ctx, sql, args...)
+}
+
+// watchContext arranges for a CancelRequest to be sent to the server if ctx
+// is canceled or its deadline expires before the returned done func is
+// called. Callers must always call done, on both the success and error
+// paths, or the watcher goroutine leaks.
+//
+// Either way ctx.Done fires -- explicit cancellation or the deadline
+// passing -- is handled identically: cancelRequest asks the server to abort
+// the in-flight query, which it does by sending an ErrorResponse on c. The
+// still-blocked Query/Exec call reads that and returns it as an ordinary
+// query error, draining through to ReadyForQuery the same way any erroring
+// query does. watchContext never touches c.conn's read deadline itself --
+// forcing the blocked read to return early with an i/o timeout instead of
+// letting it see that ErrorResponse would leave the connection desynced,
+// and ctx's own deadline is already what unblocks ctx.Done in the deadline
+// case, so there's nothing an additional socket-level deadline would add.
+func (c *Conn) watchContext(ctx context.Context) (done func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.cancelRequest()
+		case <-stopped:
+		}
+	}()
+
+	return func() {
+		close(stopped)
+	}
+}
+
+// cancelRequest opens a second connection to the server and sends a
+// CancelRequest carrying this connection's backend PID and secret key,
+// captured from the BackendKeyData message at startup. It does not wait
+// for a response; the server closes the cancel connection immediately and
+// asynchronously aborts the query on c, which then surfaces as an
+// ErrorResponse the caller drains off c in the usual way.
+func (c *Conn) cancelRequest() error {
+	cancelConn, err := net.DialTimeout(c.config.Network(), c.config.Address(), 15*time.Second)
+	if err != nil {
+		return err
+	}
+	defer cancelConn.Close()
+
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint32(buf[0:4], 16)
+	binary.BigEndian.PutUint32(buf[4:8], cancelRequestCode)
+	binary.BigEndian.PutUint32(buf[8:12], uint32(c.pid))
+	binary.BigEndian.PutUint32(buf[12:16], uint32(c.secretKey))
+
+	_, err = cancelConn.Write(buf)
+	return err
+}
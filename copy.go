@@ -0,0 +1,223 @@
+package pgx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// CopyFromSource is the interface used by CopyFrom to stream rows to the
+// server. Implementations may be backed by a slice, a database cursor, a
+// CSV reader, or anything else that can be visited once, in order.
+type CopyFromSource interface {
+	// Next returns true if there is another row to read, advancing the
+	// source. It must be called before every call to Values.
+	Next() bool
+
+	// Values returns the values for the current row.
+	Values() ([]interface{}, error)
+
+	// Err returns any error seen while iterating. CopyFrom calls it once
+	// Next returns false.
+	Err() error
+}
+
+type copyFromRows struct {
+	rows [][]interface{}
+	idx  int
+}
+
+func (ctr *copyFromRows) Next() bool {
+	ctr.idx++
+	return ctr.idx < len(ctr.rows)
+}
+
+func (ctr *copyFromRows) Values() ([]interface{}, error) {
+	return ctr.rows[ctr.idx], nil
+}
+
+func (ctr *copyFromRows) Err() error {
+	return nil
+}
+
+// CopyFromRows returns a CopyFromSource over the values in rows, in order.
+func CopyFromRows(rows [][]interface{}) CopyFromSource {
+	return &copyFromRows{rows: rows, idx: -1}
+}
+
+// copyBinarySignature is the 11-byte signature every COPY BINARY stream
+// begins with, per the PostgreSQL COPY binary format.
+var copyBinarySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// CopyFrom bulk-loads rowSrc into tableName using the PostgreSQL COPY
+// protocol, encoding each field with the same per-OID binary encoders used
+// for extended query parameters, targeting the actual destination column's
+// OID (not just whatever OID reflection would guess from the Go value's
+// type) so e.g. an int value bound for an int8 column is encoded as an
+// int8, not an int4. It returns the number of rows copied.
+//
+// CopyFrom is dramatically faster than inserting the same rows one at a
+// time, or even in a Batch, because it avoids per-row protocol overhead.
+func (c *Conn) CopyFrom(tableName string, columnNames []string, rowSrc CopyFromSource) (int, error) {
+	columnOids, err := c.copyFromColumnOids(tableName, columnNames)
+	if err != nil {
+		return 0, err
+	}
+
+	quotedColumnNames := make([]string, len(columnNames))
+	for i, cn := range columnNames {
+		quotedColumnNames[i] = quoteIdentifier(cn)
+	}
+
+	sql := fmt.Sprintf(
+		"copy %s ( %s ) from stdin binary;",
+		quoteIdentifier(tableName),
+		strings.Join(quotedColumnNames, ", "),
+	)
+
+	if err := c.sendCopyInStatement(sql); err != nil {
+		return 0, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.Write(copyBinarySignature)
+	writeInt32(buf, 0) // flags
+	writeInt32(buf, 0) // header extension length
+
+	rowCount := 0
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return rowCount, c.abortCopyIn(err)
+		}
+		if len(values) != len(columnOids) {
+			return rowCount, c.abortCopyIn(fmt.Errorf("CopyFrom: expected %d values, got %d", len(columnOids), len(values)))
+		}
+
+		writeInt16(buf, int16(len(values)))
+		for i, v := range values {
+			if v == nil {
+				writeInt32(buf, -1)
+				continue
+			}
+
+			fieldBuf := c.newWriteBuf()
+			if err := c.encodeParamForOid(fieldBuf, columnOids[i], v); err != nil {
+				return rowCount, c.abortCopyIn(err)
+			}
+			field := fieldBuf.Bytes()
+
+			writeInt32(buf, int32(len(field)))
+			buf.Write(field)
+		}
+
+		rowCount++
+
+		// Flush periodically rather than buffering the entire result set in
+		// memory, the same tradeoff a hand-written CopyData stream would make.
+		if buf.Len() > 65536 {
+			if err := c.sendCopyData(buf.Bytes()); err != nil {
+				return rowCount, err
+			}
+			buf.Reset()
+		}
+	}
+
+	if err := rowSrc.Err(); err != nil {
+		return rowCount, c.abortCopyIn(err)
+	}
+
+	writeInt16(buf, -1) // trailer
+	if err := c.sendCopyData(buf.Bytes()); err != nil {
+		return rowCount, err
+	}
+
+	if err := c.sendCopyDone(); err != nil {
+		return rowCount, err
+	}
+
+	ct, err := c.readCopyInResult()
+	if err != nil {
+		return rowCount, err
+	}
+
+	return int(ct.RowsAffected()), nil
+}
+
+// copyFromColumnOids resolves the destination OID of each column in
+// columnNames, by describing a zero-row select against tableName, so each
+// field can be encoded to match its actual destination type.
+func (c *Conn) copyFromColumnOids(tableName string, columnNames []string) ([]Oid, error) {
+	quotedColumnNames := make([]string, len(columnNames))
+	for i, cn := range columnNames {
+		quotedColumnNames[i] = quoteIdentifier(cn)
+	}
+
+	sql := fmt.Sprintf(
+		"select %s from %s limit 0",
+		strings.Join(quotedColumnNames, ", "),
+		quoteIdentifier(tableName),
+	)
+
+	ps, err := c.Prepare("", sql)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Deallocate("")
+
+	oids := make([]Oid, len(ps.FieldDescriptions))
+	for i, fd := range ps.FieldDescriptions {
+		oids[i] = fd.DataType
+	}
+
+	return oids, nil
+}
+
+// abortCopyIn tells the server to abort the in-progress COPY via CopyFail,
+// then drains the resulting ErrorResponse and ReadyForQuery so the
+// connection stays usable for the next query, and returns origErr (the
+// client-side reason the copy was aborted) rather than the server's
+// acknowledgment error.
+func (c *Conn) abortCopyIn(origErr error) error {
+	if err := c.sendCopyFail(origErr.Error()); err != nil {
+		return err
+	}
+
+	if _, err := c.readCopyInResult(); err != nil {
+		// The server always errors a failed COPY; that's expected and not
+		// the failure we want to surface to the caller.
+	}
+
+	return origErr
+}
+
+// readCopyInResult reads the server's single response to a completed (or
+// failed) COPY ... FROM STDIN: a CommandComplete on success or an
+// ErrorResponse on failure, always followed by ReadyForQuery, which must be
+// drained either way so the connection stays usable for the next query.
+func (c *Conn) readCopyInResult() (CommandTag, error) {
+	ct, copyErr := c.readCommandComplete()
+
+	if err := c.readReadyForQuery(); err != nil {
+		return "", err
+	}
+
+	return ct, copyErr
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+func writeInt16(buf *bytes.Buffer, n int16) {
+	buf.WriteByte(byte(n >> 8))
+	buf.WriteByte(byte(n))
+}
+
+func writeInt32(buf *bytes.Buffer, n int32) {
+	buf.WriteByte(byte(n >> 24))
+	buf.WriteByte(byte(n >> 16))
+	buf.WriteByte(byte(n >> 8))
+	buf.WriteByte(byte(n))
+}
+
@@ -0,0 +1,295 @@
+package pgx
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultPingInterval is how often a Listener runs SELECT 1 against its
+// connection to detect a half-open TCP connection that hasn't yet failed a
+// read or write.
+const defaultPingInterval = 60 * time.Second
+
+// requestPollInterval bounds how long readUntilError ever waits on
+// WaitForNotification in one call, so it comes back around to service a
+// pending Listen/Unlisten request (or a ping that's come due) promptly
+// instead of only at the next notification or ping interval.
+const requestPollInterval = time.Second
+
+// Listener is a LISTEN/NOTIFY subscription that survives connection loss.
+// It maintains its own Conn, re-establishing it with exponential backoff
+// and re-issuing every outstanding LISTEN after a reconnect, so callers
+// don't have to. Reconnects are announced on Events so callers can
+// invalidate whatever they cached under the assumption notifications
+// would keep arriving uninterrupted.
+//
+// Every use of the underlying Conn -- pinging, issuing LISTEN/UNLISTEN, and
+// reading notifications -- happens on the single goroutine running
+// readUntilError. Listen and Unlisten hand their statement to that
+// goroutine over listenReq rather than calling conn.Exec themselves, since
+// Conn isn't safe for concurrent use.
+type Listener struct {
+	config       ConnConfig
+	minReconnect time.Duration
+	maxReconnect time.Duration
+	pingInterval time.Duration
+
+	// Notify delivers every notification received on a channel this
+	// Listener is subscribed to.
+	Notify chan *Notification
+
+	// Events delivers a message ("reconnected", "listening") whenever the
+	// underlying connection changes state.
+	Events chan string
+
+	listenReq chan *listenRequest
+
+	mu       sync.Mutex
+	channels map[string]struct{}
+	conn     *Conn
+	closed   bool
+	closeCh  chan struct{}
+}
+
+// listenRequest asks the goroutine currently running readUntilError to run
+// sql against its conn and report back whether it succeeded.
+type listenRequest struct {
+	sql  string
+	resp chan error
+}
+
+// NewListener creates a Listener and starts its reconnect loop in the
+// background. The returned Listener has no subscriptions until Listen is
+// called.
+func NewListener(config ConnConfig, minReconnect, maxReconnect time.Duration) *Listener {
+	l := &Listener{
+		config:       config,
+		minReconnect: minReconnect,
+		maxReconnect: maxReconnect,
+		pingInterval: defaultPingInterval,
+		Notify:       make(chan *Notification, 32),
+		Events:       make(chan string, 8),
+		listenReq:    make(chan *listenRequest),
+		channels:     make(map[string]struct{}),
+		closeCh:      make(chan struct{}),
+	}
+
+	go l.manage()
+
+	return l
+}
+
+// Listen subscribes to channel. If the Listener is currently connected, the
+// LISTEN statement is issued immediately; regardless, channel is remembered
+// so it is (re-)issued after every future reconnect.
+func (l *Listener) Listen(channel string) error {
+	l.mu.Lock()
+	l.channels[channel] = struct{}{}
+	connected := l.conn != nil
+	l.mu.Unlock()
+
+	if !connected {
+		return nil
+	}
+
+	if err := l.execOnOwner(fmt.Sprintf("listen %s", quoteIdentifier(channel))); err != nil {
+		return err
+	}
+
+	select {
+	case l.Events <- "listening":
+	default:
+	}
+	return nil
+}
+
+// Unlisten unsubscribes from channel.
+func (l *Listener) Unlisten(channel string) error {
+	l.mu.Lock()
+	delete(l.channels, channel)
+	connected := l.conn != nil
+	l.mu.Unlock()
+
+	if !connected {
+		return nil
+	}
+	return l.execOnOwner(fmt.Sprintf("unlisten %s", quoteIdentifier(channel)))
+}
+
+// execOnOwner hands sql to the goroutine currently running readUntilError,
+// the only goroutine allowed to touch the Listener's conn, and waits for
+// the result. It gives up if the Listener is closed before the request is
+// picked up or answered, which can happen if the connection drops between
+// the connected check in Listen/Unlisten and the request being sent.
+func (l *Listener) execOnOwner(sql string) error {
+	req := &listenRequest{sql: sql, resp: make(chan error, 1)}
+
+	select {
+	case l.listenReq <- req:
+	case <-l.closeCh:
+		return fmt.Errorf("pgx: listener closed")
+	}
+
+	select {
+	case err := <-req.resp:
+		return err
+	case <-l.closeCh:
+		return fmt.Errorf("pgx: listener closed")
+	}
+}
+
+// Close stops the reconnect loop and closes the underlying connection, if
+// any. Notify and Events are closed once the background goroutine exits.
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	conn := l.conn
+	l.mu.Unlock()
+
+	close(l.closeCh)
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// manage is the reconnect loop: connect, subscribe to every remembered
+// channel, ping periodically, and read notifications until the connection
+// fails, then back off and try again.
+func (l *Listener) manage() {
+	defer close(l.Notify)
+	defer close(l.Events)
+
+	backoff := l.minReconnect
+
+	for {
+		select {
+		case <-l.closeCh:
+			return
+		default:
+		}
+
+		conn, err := Connect(l.config)
+		if err != nil {
+			if !l.sleepBackoff(&backoff) {
+				return
+			}
+			continue
+		}
+
+		l.mu.Lock()
+		l.conn = conn
+		channels := make([]string, 0, len(l.channels))
+		for ch := range l.channels {
+			channels = append(channels, ch)
+		}
+		l.mu.Unlock()
+
+		if err := l.resubscribe(conn, channels); err != nil {
+			conn.Close()
+			if !l.sleepBackoff(&backoff) {
+				return
+			}
+			continue
+		}
+
+		backoff = l.minReconnect
+		select {
+		case l.Events <- "reconnected":
+		default:
+		}
+		if len(channels) > 0 {
+			select {
+			case l.Events <- "listening":
+			default:
+			}
+		}
+
+		l.readUntilError(conn)
+
+		l.mu.Lock()
+		l.conn = nil
+		l.mu.Unlock()
+	}
+}
+
+func (l *Listener) resubscribe(conn *Conn, channels []string) error {
+	for _, ch := range channels {
+		if _, err := conn.Exec(fmt.Sprintf("listen %s", quoteIdentifier(ch))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readUntilError is the sole owner of conn for as long as it runs: it pings,
+// issues LISTEN/UNLISTEN on behalf of Listen/Unlisten, and reads
+// notifications, all from this one goroutine, so conn never sees concurrent
+// use. It returns once conn fails or the Listener is closed.
+func (l *Listener) readUntilError(conn *Conn) {
+	nextPing := time.Now().Add(l.pingInterval)
+
+	for {
+		select {
+		case <-l.closeCh:
+			return
+		case req := <-l.listenReq:
+			_, err := conn.Exec(req.sql)
+			req.resp <- err
+			continue
+		default:
+		}
+
+		wait := requestPollInterval
+		if until := time.Until(nextPing); until < wait {
+			wait = until
+		}
+		if wait < 0 {
+			wait = 0
+		}
+
+		n, err := conn.WaitForNotification(wait)
+		switch err {
+		case nil:
+			select {
+			case l.Notify <- n:
+			case <-l.closeCh:
+				return
+			}
+		case ErrNotificationTimeout:
+			// Nothing arrived within wait; go back around to recheck
+			// listenReq, the close signal, and whether a ping is due.
+		default:
+			return
+		}
+
+		if !time.Now().Before(nextPing) {
+			if _, err := conn.Exec("select 1"); err != nil {
+				return
+			}
+			nextPing = time.Now().Add(l.pingInterval)
+		}
+	}
+}
+
+// sleepBackoff waits for *backoff, doubling it up to maxReconnect, and
+// reports whether the Listener is still open.
+func (l *Listener) sleepBackoff(backoff *time.Duration) bool {
+	select {
+	case <-l.closeCh:
+		return false
+	case <-time.After(*backoff):
+	}
+
+	*backoff *= 2
+	if *backoff > l.maxReconnect {
+		*backoff = l.maxReconnect
+	}
+
+	return true
+}
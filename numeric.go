@@ -0,0 +1,253 @@
+package pgx
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// NumericOid is the OID PostgreSQL uses for the numeric/decimal type.
+const NumericOid = 1700
+
+var (
+	bigTen                = big.NewInt(10)
+	bigTenK               = big.NewInt(10000)
+	numericNaNSign uint16 = 0xC000
+	numericNegSign uint16 = 0x4000
+)
+
+// Numeric represents the PostgreSQL numeric/decimal type as an arbitrary
+// precision mantissa (Int) and a base-10 exponent (Exp), so that
+// Int * 10^Exp equals the represented value. float64 cannot hold this
+// precision, which is why this driver has never supported numeric until
+// now.
+//
+// The zero value is not a valid Numeric; use a literal with Int and Exp
+// set, or decode one off the wire.
+type Numeric struct {
+	Int *big.Int
+	Exp int32
+	NaN bool
+}
+
+// Scan implements the Scanner interface.
+func (n *Numeric) Scan(r *ValueReader) error {
+	if r.Type().DataType != NumericOid {
+		return fmt.Errorf("Numeric.Scan cannot decode OID %d", r.Type().DataType)
+	}
+
+	if r.Len() == -1 {
+		return fmt.Errorf("Numeric.Scan cannot decode null into Numeric, use NullNumeric")
+	}
+
+	switch r.Type().FormatCode {
+	case BinaryFormatCode:
+		return n.DecodeBinary(r)
+	default:
+		return fmt.Errorf("Numeric.Scan does not support format code %v", r.Type().FormatCode)
+	}
+}
+
+// DecodeBinary decodes the numeric wire format (ndigits, weight, sign,
+// dscale, then ndigits base-10000 digit words) into n.
+//
+// The digit words alone only place the value to a multiple-of-4 exponent
+// (groupExp below), since that's the granularity PostgreSQL groups decimal
+// digits at on the wire. dscale, the number of digits meant to appear after
+// the decimal point, is what pins down the exact exponent for a fractional
+// value: Exp is set to -dscale and the surplus trailing zero digits
+// group-alignment introduced are divided back out of Int, so e.g. a wire
+// encoding of 123.45 (digits grouped as 1234500 at exponent -4, dscale 2)
+// decodes back to Int=12345, Exp=-2, matching whatever was originally
+// encoded rather than the coarser group-aligned form. Where the last digit
+// word sent lies above the dscale position (PostgreSQL omits trailing
+// all-zero words), the missing zeros are multiplied back into Int instead.
+//
+// For a whole number (dscale 0), there's no equivalent field: PostgreSQL's
+// numeric wire format has no way to distinguish, say, Int=100,Exp=2 from
+// Int=1,Exp=4 -- both are the value 10000, stored identically on the wire.
+// DecodeBinary resolves that ambiguity by returning the most reduced form,
+// stripping trailing zeros out of Int into Exp for as long as Int stays
+// exactly divisible by 10.
+func (n *Numeric) DecodeBinary(r *ValueReader) error {
+	ndigits := r.ReadInt16()
+	weight := r.ReadInt16()
+	// sign and dscale are wire uint16s; ValueReader only exposes ReadInt16,
+	// so read the same two bytes as int16 and reinterpret the bit pattern.
+	sign := uint16(r.ReadInt16())
+	dscale := uint16(r.ReadInt16())
+
+	if sign == numericNaNSign {
+		n.NaN = true
+		n.Int = nil
+		n.Exp = 0
+		return r.Err()
+	}
+
+	if ndigits == 0 {
+		// PostgreSQL's canonical wire representation of zero carries no
+		// digit words at all, regardless of weight or dscale.
+		n.Int = big.NewInt(0)
+		n.Exp = 0
+		n.NaN = false
+		return r.Err()
+	}
+
+	accum := new(big.Int)
+	for i := int16(0); i < ndigits; i++ {
+		accum.Mul(accum, bigTenK)
+		accum.Add(accum, big.NewInt(int64(r.ReadInt16())))
+	}
+
+	groupExp := 4 * (int32(weight) - int32(ndigits) + 1)
+
+	exp := groupExp
+	if dscale > 0 {
+		exp = -int32(dscale)
+	} else {
+		// No dscale to pin the exponent down, so find it by probing how
+		// many trailing zeros Int actually has, without mutating accum --
+		// the shift below performs the division exactly once.
+		probe := new(big.Int).Set(accum)
+		rem := new(big.Int)
+		for probe.Sign() != 0 {
+			q, m := new(big.Int).QuoRem(probe, bigTen, rem)
+			if m.Sign() != 0 {
+				break
+			}
+			probe = q
+			exp++
+		}
+	}
+
+	if exp > groupExp {
+		shift := new(big.Int).Exp(bigTen, big.NewInt(int64(exp-groupExp)), nil)
+		accum.Quo(accum, shift)
+	} else if exp < groupExp {
+		// PostgreSQL drops trailing all-zero digit words, so the last word
+		// sent can sit above the dscale position; scale Int back up to it.
+		shift := new(big.Int).Exp(bigTen, big.NewInt(int64(groupExp-exp)), nil)
+		accum.Mul(accum, shift)
+	}
+
+	if sign == numericNegSign {
+		accum.Neg(accum)
+	}
+
+	n.Int = accum
+	n.Exp = exp
+	n.NaN = false
+
+	return r.Err()
+}
+
+// FormatCode implements the Encoder interface; Numeric always encodes in
+// binary format.
+func (n Numeric) FormatCode() int16 {
+	return BinaryFormatCode
+}
+
+// Encode implements the Encoder interface.
+func (n Numeric) Encode(w *WriteBuf, oid Oid) error {
+	if oid != NumericOid {
+		return fmt.Errorf("cannot encode Numeric into OID %d", oid)
+	}
+	return n.EncodeBinary(w)
+}
+
+// EncodeBinary writes n in the numeric wire format.
+func (n Numeric) EncodeBinary(w *WriteBuf) error {
+	if n.NaN {
+		w.WriteInt32(8)
+		w.WriteInt16(0)
+		w.WriteInt16(0)
+		w.WriteInt16(int16(numericNaNSign))
+		w.WriteInt16(0)
+		return nil
+	}
+
+	sign := uint16(0)
+	mantissa := new(big.Int).Set(n.Int)
+	if mantissa.Sign() < 0 {
+		sign = numericNegSign
+		mantissa.Neg(mantissa)
+	}
+
+	// dscale records the true number of digits after the decimal point, so
+	// DecodeBinary can recover Exp exactly even though the digit groups
+	// below are only ever aligned to a multiple of 4. It must be captured
+	// from Exp before the alignment loop changes it.
+	dscale := uint16(0)
+	if n.Exp < 0 {
+		dscale = uint16(-n.Exp)
+	}
+
+	// The wire format only carries digits in groups of 4 decimal places, so
+	// align Exp to a multiple of 4 before splitting into base-10000 digits.
+	exp := n.Exp
+	for exp%4 != 0 {
+		mantissa.Mul(mantissa, bigTen)
+		exp--
+	}
+
+	var digits []int16
+	rem := new(big.Int)
+	for mantissa.Sign() != 0 {
+		mantissa.DivMod(mantissa, bigTenK, rem)
+		digits = append([]int16{int16(rem.Int64())}, digits...)
+	}
+	if len(digits) == 0 {
+		digits = []int16{0}
+	}
+
+	weight := int16(len(digits)-1) + int16(exp/4)
+
+	w.WriteInt32(int32(8 + 2*len(digits)))
+	w.WriteInt16(int16(len(digits)))
+	w.WriteInt16(weight)
+	w.WriteInt16(int16(sign))
+	w.WriteInt16(int16(dscale))
+	for _, d := range digits {
+		w.WriteInt16(d)
+	}
+
+	return nil
+}
+
+// NullNumeric represents a Numeric that may be null. NullNumeric implements
+// the Scanner and Encoder interfaces so it may be used both as an argument
+// to Query[Row] and a destination for Scan.
+type NullNumeric struct {
+	Numeric Numeric
+	Valid   bool // Valid is true if Numeric is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (n *NullNumeric) Scan(r *ValueReader) error {
+	if r.Len() == -1 {
+		n.Numeric, n.Valid = Numeric{}, false
+		return nil
+	}
+
+	n.Valid = true
+	return n.Numeric.Scan(r)
+}
+
+// Encode implements the Encoder interface.
+func (n NullNumeric) Encode(w *WriteBuf, oid Oid) error {
+	if oid != NumericOid {
+		return fmt.Errorf("cannot encode NullNumeric into OID %d", oid)
+	}
+
+	if !n.Valid {
+		w.WriteInt32(-1)
+		return nil
+	}
+
+	return n.Numeric.Encode(w, oid)
+}
+
+// FormatCode implements the Encoder interface; NullNumeric always encodes
+// in binary format.
+func (n NullNumeric) FormatCode() int16 {
+	return BinaryFormatCode
+}
@@ -0,0 +1,68 @@
+package pgx_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx"
+)
+
+func TestNumericTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	tests := []struct {
+		n pgx.Numeric
+	}{
+		{pgx.Numeric{Int: big.NewInt(0), Exp: 0}},
+		{pgx.Numeric{Int: big.NewInt(1), Exp: 0}},
+		{pgx.Numeric{Int: big.NewInt(-1), Exp: 0}},
+		{pgx.Numeric{Int: big.NewInt(12345), Exp: -2}},
+		{pgx.Numeric{Int: big.NewInt(-12345), Exp: -2}},
+		{pgx.Numeric{Int: big.NewInt(12345), Exp: 4}},
+		{pgx.Numeric{Int: new(big.Int).Exp(big.NewInt(10), big.NewInt(40), nil), Exp: -10}},
+		{pgx.Numeric{NaN: true}},
+	}
+
+	for i, tt := range tests {
+		var actual pgx.Numeric
+
+		err := conn.QueryRow("select $1::numeric", tt.n).Scan(&actual)
+		if err != nil {
+			t.Errorf("%d. Unexpected failure: %v (n -> %v)", i, err, tt.n)
+			continue
+		}
+
+		if tt.n.NaN != actual.NaN {
+			t.Errorf("%d. Expected NaN %v, got %v", i, tt.n.NaN, actual.NaN)
+			continue
+		}
+
+		if !tt.n.NaN && (tt.n.Exp != actual.Exp || tt.n.Int.Cmp(actual.Int) != 0) {
+			t.Errorf("%d. Expected %v*10^%d, got %v*10^%d", i, tt.n.Int, tt.n.Exp, actual.Int, actual.Exp)
+		}
+
+		ensureConnValid(t, conn)
+	}
+}
+
+func TestNullNumericTranscode(t *testing.T) {
+	t.Parallel()
+
+	conn := mustConnect(t, *defaultConnConfig)
+	defer closeConn(t, conn)
+
+	var actual pgx.NullNumeric
+
+	err := conn.QueryRow("select $1::numeric", pgx.NullNumeric{Valid: false}).Scan(&actual)
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+	if actual.Valid {
+		t.Errorf("Expected Valid to be false, but it was true")
+	}
+
+	ensureConnValid(t, conn)
+}
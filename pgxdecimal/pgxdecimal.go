@@ -0,0 +1,149 @@
+// Package pgxdecimal registers pgx.Codec support for
+// github.com/shopspring/decimal.Decimal, *big.Float, and *big.Rat against
+// PostgreSQL's numeric type, so callers who already use one of those types
+// for money or other exact-precision values can encode and decode them
+// directly wherever a pgx.TypeRegistry is consulted (see its doc for which
+// paths do), instead of round-tripping through pgx.Numeric.
+package pgxdecimal
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx"
+	"github.com/shopspring/decimal"
+)
+
+// Register installs the numeric codec on tr.
+func Register(tr *pgx.TypeRegistry) {
+	tr.RegisterType("numeric", pgx.NumericOid, decimalCodec{})
+}
+
+type decimalCodec struct{}
+
+// FormatCode reports that decimalCodec only supports binary format.
+func (decimalCodec) FormatCode() int16 {
+	return pgx.BinaryFormatCode
+}
+
+func (decimalCodec) EncodeBinary(w *pgx.WriteBuf, value interface{}) error {
+	num, err := toNumeric(value)
+	if err != nil {
+		return err
+	}
+	return num.EncodeBinary(w)
+}
+
+func (decimalCodec) EncodeText(w *pgx.WriteBuf, value interface{}) error {
+	return fmt.Errorf("pgxdecimal: text encoding not supported, use binary format")
+}
+
+func (decimalCodec) DecodeBinary(r *pgx.ValueReader, value interface{}) error {
+	var num pgx.Numeric
+	if err := num.DecodeBinary(r); err != nil {
+		return err
+	}
+	if num.NaN {
+		return fmt.Errorf("pgxdecimal: cannot represent numeric NaN as %T", value)
+	}
+
+	switch dest := value.(type) {
+	case *decimal.Decimal:
+		*dest = decimal.NewFromBigInt(num.Int, num.Exp)
+	case **big.Float:
+		f := new(big.Float).SetInt(num.Int)
+		if num.Exp != 0 {
+			scale := new(big.Float).SetInt(pow10(num.Exp))
+			if num.Exp > 0 {
+				f.Mul(f, scale)
+			} else {
+				f.Quo(f, scale)
+			}
+		}
+		*dest = f
+	case **big.Rat:
+		q := new(big.Rat).SetInt(num.Int)
+		if num.Exp != 0 {
+			scale := new(big.Rat).SetInt(pow10(num.Exp))
+			if num.Exp > 0 {
+				q.Mul(q, scale)
+			} else {
+				q.Quo(q, scale)
+			}
+		}
+		*dest = q
+	default:
+		return fmt.Errorf("pgxdecimal: cannot decode numeric into %T", value)
+	}
+
+	return nil
+}
+
+func (decimalCodec) DecodeText(r *pgx.ValueReader, value interface{}) error {
+	return fmt.Errorf("pgxdecimal: text decoding not supported, use binary format")
+}
+
+func toNumeric(value interface{}) (pgx.Numeric, error) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return pgx.Numeric{Int: v.Coefficient(), Exp: v.Exponent()}, nil
+	case *big.Float:
+		d, err := decimal.NewFromString(v.Text('f', -1))
+		if err != nil {
+			return pgx.Numeric{}, fmt.Errorf("pgxdecimal: cannot encode %v as numeric: %v", v, err)
+		}
+		return pgx.Numeric{Int: d.Coefficient(), Exp: d.Exponent()}, nil
+	case *big.Rat:
+		return ratToNumeric(v)
+	default:
+		return pgx.Numeric{}, fmt.Errorf("pgxdecimal: cannot encode %T as numeric", value)
+	}
+}
+
+// ratToNumeric converts v to an exact Int*10^Exp representation by
+// factoring 2s and 5s out of its reduced denominator. Only rationals whose
+// denominator (after reduction) has no other prime factors terminate in
+// decimal; anything else (e.g. 1/3) is a repeating decimal that numeric
+// cannot hold exactly, so it is rejected instead of silently truncated,
+// which would defeat the point of using *big.Rat for exact precision.
+func ratToNumeric(v *big.Rat) (pgx.Numeric, error) {
+	num := new(big.Int).Set(v.Num())
+	den := new(big.Int).Set(v.Denom())
+
+	exp := int32(0)
+	two := big.NewInt(2)
+	five := big.NewInt(5)
+	one := big.NewInt(1)
+
+	for den.Cmp(one) != 0 {
+		q, rem := new(big.Int), new(big.Int)
+
+		q.DivMod(den, five, rem)
+		if rem.Sign() == 0 {
+			num.Mul(num, two)
+			den = q
+			exp--
+			continue
+		}
+
+		q.DivMod(den, two, rem)
+		if rem.Sign() == 0 {
+			num.Mul(num, five)
+			den = q
+			exp--
+			continue
+		}
+
+		return pgx.Numeric{}, fmt.Errorf("pgxdecimal: %v is not exactly representable as a decimal numeric (repeating fraction)", v)
+	}
+
+	return pgx.Numeric{Int: num, Exp: exp}, nil
+}
+
+// pow10 returns 10^|exp| as a *big.Int.
+func pow10(exp int32) *big.Int {
+	if exp < 0 {
+		exp = -exp
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}